@@ -4,9 +4,11 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"strings"
+	"time"
 )
 
 // DefaultPrompt is the default value of Cmd.Prompt
@@ -17,6 +19,22 @@ const DefaultPrompt = "> "
 // If err is not nil then execution of the command loop is terminated.
 type CmdFn func(args []string) (out string, err error)
 
+// CmdFnContext is like CmdFn but additionally receives a context that is
+// canceled if the command should stop running, either because the user
+// interrupted the loop with Ctrl-C or because Cmd.CommandTimeout elapsed.
+// Handlers that perform long running work should watch ctx.Done() and
+// return promptly when it's closed. Only LoopContext cancels the context on
+// Ctrl-C; Loop, LoopReadline and RunScript also run CmdFnContext values,
+// with a context that only CommandTimeout cancels. See Cmd.ContextCommands.
+type CmdFnContext func(ctx context.Context, args []string) (out string, err error)
+
+// CmdFnRESP is the function type used to define commands exposed by
+// RESPLoop. args holds the raw argument bytes as received over the RESP
+// protocol. The returned reply is encoded based on its type - see
+// RESPLoop for the supported types - and a non-nil err is sent back as a
+// RESP error instead.
+type CmdFnRESP func(args [][]byte) (reply interface{}, err error)
+
 // Cmd is an interactive command interpreter. It's started by calling the Loop method.
 // Instances of Cmd should be constructed with the New function.
 type Cmd struct {
@@ -31,8 +49,40 @@ type Cmd struct {
 
 	// Commands is a map of command functions for valid commands.
 	// If a command is not in this map then Default will be called.
+	//
+	// New registers a "help" command here unless one is already present;
+	// see AddCommand and Group for richer, documented commands and
+	// nested subcommand trees.
 	Commands map[string]CmdFn
 
+	// ContextCommands is a map of context-aware command functions,
+	// consulted before Commands by every loop that runs plain text lines
+	// (Loop, LoopReadline, LoopContext and RunScript). Only LoopContext
+	// cancels their context on Ctrl-C.
+	ContextCommands map[string]CmdFnContext
+
+	// CommandTimeout, if non-zero, bounds how long a single command
+	// looked up in ContextCommands may run before its context is
+	// canceled. It has no effect on commands in Commands, which do not
+	// receive a context.
+	CommandTimeout time.Duration
+
+	// RESPCommands is a map of RESP-aware command functions, consulted
+	// before Commands by RESPLoop. It has no effect on Loop, LoopReadline
+	// or LoopContext.
+	RESPCommands map[string]CmdFnRESP
+
+	// Session holds per-connection state, such as an authenticated
+	// user's identity, for use by middleware registered with Use (see
+	// AuthMiddleware). Since each connection typically gets its own Cmd
+	// (see the TCP example), Session is naturally scoped per connection.
+	Session map[string]interface{}
+
+	// middleware is the chain installed by Use, applied to commands
+	// looked up in Commands. The first registered middleware is
+	// outermost.
+	middleware []Middleware
+
 	// Default is called when a command is received that does not match
 	// any function in the Commands map. The line argument will contain
 	// the full contents of the line received.
@@ -58,16 +108,62 @@ type Cmd struct {
 	// The first token is the name of the command that will be called,
 	// while the rest of the tokens are passed as arguments to the command.
 	//
+	// If Tokens returns a non-nil error the line is not executed and the
+	// error is returned from one (and thus from Loop).
+	//
 	// If Tokens is not set then strings.Fields is used.
-	Tokens func(line string) (tokens []string)
+	Tokens func(line string) (tokens []string, err error)
 
 	// LastLine contains the last non-empty line received
 	LastLine string
+
+	// Completer returns candidate completions for the argument token
+	// under the cursor at position pos in line. It is consulted by
+	// LoopReadline when the user presses TAB and the word under the
+	// cursor is not the command name itself, which is instead completed
+	// from Commands.
+	//
+	// If Completer is nil no argument completion is offered.
+	Completer func(line string, pos int) (candidates []string)
+
+	// cmdDocs holds the Command descriptors registered via AddCommand,
+	// keyed by name, for use by the "help" built-in.
+	cmdDocs map[string]*Command
+
+	// ContinueOnError, when set, makes RunScript write a command's error
+	// to Out and continue with the next line instead of stopping.
+	ContinueOnError bool
+
+	// HistoryFile, if set, is the path LoadHistory and SaveHistory read
+	// from and write to, letting LoopReadline's history recall and
+	// LastLine survive restarts.
+	HistoryFile string
+
+	// history holds the lines LoopReadline has recorded for up/down
+	// arrow recall and Ctrl-R search, and the lines LoadHistory read from
+	// HistoryFile. See addHistory.
+	history []string
+}
+
+// addHistory appends line to c.history, skipping empty lines and
+// consecutive duplicates.
+func (c *Cmd) addHistory(line string) {
+	if line == "" {
+		return
+	}
+	if n := len(c.history); n > 0 && c.history[n-1] == line {
+		return
+	}
+	c.history = append(c.history, line)
 }
 
 // New creates a new Cmd with the commands from c that communicates via in and out.
 func New(c map[string]CmdFn, in io.Reader, out io.Writer) *Cmd {
-	cmd := Cmd{In: in, Out: out, Prompt: DefaultPrompt, LastLine: "", Commands: c}
+	commands := make(map[string]CmdFn, len(c)+1)
+	for name, fn := range c {
+		commands[name] = fn
+	}
+	cmd := Cmd{In: in, Out: out, Prompt: DefaultPrompt, LastLine: "", Commands: commands}
 	cmd.EmptyLine = func() (string, error) {
 		if len(cmd.LastLine) > 0 {
 			return "", cmd.one(cmd.LastLine)
@@ -77,43 +173,76 @@ func New(c map[string]CmdFn, in io.Reader, out io.Writer) *Cmd {
 	cmd.Default = func(line string) (string, error) {
 		return fmt.Sprintf("unrecognized command: %s\n", strings.Fields(line)[0]), nil
 	}
-	cmd.Tokens = strings.Fields
+	cmd.Tokens = func(line string) ([]string, error) {
+		return strings.Fields(line), nil
+	}
+	if _, ok := cmd.Commands["help"]; !ok {
+		cmd.Commands["help"] = cmd.help
+	}
+	cmd.Session = map[string]interface{}{}
 	return &cmd
 }
 
-func (c *Cmd) parseLine(line string) (cmd string, args []string) {
+// parseLine tokenizes line and splits it into a command name and its
+// arguments. ok is false if line held no tokens at all (as opposed to a
+// first token that happens to be the empty string, which Tokens
+// implementations such as ShellTokens can produce from a quoted ""
+// argument) - only that case should be treated as an empty line.
+func (c *Cmd) parseLine(line string) (cmd string, args []string, ok bool, err error) {
 	line = strings.TrimSpace(line)
 	if len(line) == 0 {
-		return
+		return "", nil, false, nil
 	}
 
-	tokens := c.Tokens(line)
+	tokens, err := c.Tokens(line)
+	if err != nil {
+		return "", nil, false, err
+	}
 	if len(tokens) == 0 {
-		return
+		return "", nil, false, nil
 	}
 	cmd = tokens[0]
 	if len(tokens) > 1 {
 		args = tokens[1:]
 	}
-	return
+	return cmd, args, true, nil
 }
 
 // one parses one line of input and executes a command.
 // The output of the command is sent to c.Out.
 func (c *Cmd) one(line string) error {
-	cmd, args := c.parseLine(line)
+	return c.oneContext(context.Background(), line)
+}
+
+// oneContext is the shared implementation behind one and LoopContext. A
+// command found in ContextCommands is run with ctx (bounded by
+// CommandTimeout if set); otherwise Commands and Default behave exactly as
+// they do for one.
+func (c *Cmd) oneContext(ctx context.Context, line string) error {
+	cmd, args, ok, err := c.parseLine(line)
+	if err != nil {
+		return err
+	}
 
 	var msg string
 	var cmderr error
 
-	if cmd == "" {
+	if !ok {
 		msg, cmderr = c.EmptyLine()
 	} else {
-		c.LastLine = line[:]
-		if fn := c.Commands[cmd]; fn == nil {
+		c.LastLine = line
+		switch {
+		case c.ContextCommands[cmd] != nil:
+			if c.CommandTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, c.CommandTimeout)
+				defer cancel()
+			}
+			msg, cmderr = c.ContextCommands[cmd](ctx, args)
+		case c.Commands[cmd] != nil:
+			msg, cmderr = c.wrap(c.Commands[cmd])(args)
+		default:
 			msg, cmderr = c.Default(line)
-		} else {
-			msg, cmderr = fn(args)
 		}
 	}
 
@@ -150,5 +279,4 @@ func (c *Cmd) Loop() error {
 			return err
 		}
 	}
-	panic("unreachable")
 }
@@ -1,15 +1,14 @@
-package cmd_test
+package cmd
 
 import (
 	"bufio"
 	"bytes"
 	"fmt"
-	"github.com/kisielk/cmd"
 	"io"
 	"testing"
 )
 
-var commands = map[string]cmd.CmdFn{
+var commands = map[string]CmdFn{
 	"good": func(args []string) (string, error) {
 		return fmt.Sprintf("good %v\n", args), nil
 	},
@@ -32,13 +31,13 @@ var tests = []struct {
 
 func TestOne(t *testing.T) {
 	out := &bytes.Buffer{}
-	c := cmd.New(commands, nil, out)
+	c := New(commands, nil, out)
 
 	for i, test := range tests {
-		if err := c.One([]byte(test.In)); !test.ShouldError && err != nil {
+		if err := c.one(test.In); !test.ShouldError && err != nil {
 			t.Fatalf("%d: unexpected error: %s", i, err)
 		} else if test.ShouldError && err == nil {
-			t.Fatalf("%d: expected error but got nil")
+			t.Fatalf("%d: expected error but got nil", i)
 		}
 
 		if outMsg := out.String(); outMsg != test.Out {
@@ -48,16 +47,40 @@ func TestOne(t *testing.T) {
 	}
 }
 
+func TestOneEmptyQuotedCommandIsNotEmptyLine(t *testing.T) {
+	out := &bytes.Buffer{}
+	c := New(map[string]CmdFn{
+		"good": func(args []string) (string, error) {
+			return fmt.Sprintf("good %v\n", args), nil
+		},
+	}, nil, out)
+	c.Tokens = ShellTokens
+
+	if err := c.one("good"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	out.Reset()
+
+	// A quoted "" argument tokenizes to a first token that is the empty
+	// string, which is a real (if unrecognized) command name - it must
+	// not be mistaken for a blank line and repeat "good".
+	if err := c.one(`""`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := out.String(), "unrecognized command: \"\"\n"; got != want {
+		t.Fatalf("bad output: got %q, want %q", got, want)
+	}
+}
+
 func TestLoop(t *testing.T) {
 	in, inw := io.Pipe()
 	outr, out := io.Pipe()
 	outbuf := bufio.NewReader(outr)
-	c := cmd.New(commands, in, out)
+	c := New(commands, in, out)
 
 	go func() {
-		err := c.Loop()
-		if err != nil {
-			t.Fatal(err)
+		if err := c.Loop(); err != nil {
+			panic(err)
 		}
 	}()
 
@@ -78,7 +101,7 @@ func TestLoop(t *testing.T) {
 		fmt.Fprintln(inw, test.In)
 		outMsg, err := outbuf.ReadBytes('\n')
 		if err != nil {
-			t.Fatalf("%d: couldn't read output: %s", err)
+			t.Fatalf("%d: couldn't read output: %s", i, err)
 		}
 		if o := string(outMsg); o != test.Out {
 			t.Fatalf("%d: bad output: got %q, want %q", i, o, test.Out)
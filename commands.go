@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Command is a named command with documentation, for registration with
+// Cmd.AddCommand or NewCommands. It's a richer alternative to adding an
+// entry directly to Cmd.Commands, used when the "help" built-in should be
+// able to describe the command.
+type Command struct {
+	// Name is the command's name, as typed by the user.
+	Name string
+
+	// Short is a one-line description, shown in the command listing
+	// produced by "help" with no arguments.
+	Short string
+
+	// Long is a longer description, shown by "help <name>". If Long is
+	// empty, Short is shown instead.
+	Long string
+
+	// Run is the command's implementation.
+	Run CmdFn
+
+	// Group, if non-nil, is a nested command tree rooted at this command
+	// (see Group). Run is typically Group.Run. When set, "help <name>"
+	// lists the group's subcommands instead of Long.
+	Group *Group
+}
+
+// AddCommand registers cmd.Run under cmd.Name in c.Commands, and records
+// its Short, Long and Group for use by the "help" built-in.
+func (c *Cmd) AddCommand(cmd Command) {
+	if c.Commands == nil {
+		c.Commands = map[string]CmdFn{}
+	}
+	c.Commands[cmd.Name] = cmd.Run
+
+	if c.cmdDocs == nil {
+		c.cmdDocs = map[string]*Command{}
+	}
+	doc := cmd
+	c.cmdDocs[cmd.Name] = &doc
+}
+
+// NewCommands is like New but takes a list of Command descriptors instead
+// of a plain map, so that "help" can describe each command.
+func NewCommands(commands []Command, in io.Reader, out io.Writer) *Cmd {
+	c := New(nil, in, out)
+	for _, cmd := range commands {
+		c.AddCommand(cmd)
+	}
+	return c
+}
+
+// Group is a named collection of subcommands, letting related commands
+// such as "user add", "user remove" and "user list" be registered as a
+// single nested command tree rather than hand-parsing args in one
+// handler.
+type Group struct {
+	// Cmd holds the subcommands and their documentation. Only its
+	// Commands map (and the documentation recorded by AddCommand) are
+	// used; In, Out, Prompt and the other Loop-related fields are unused.
+	*Cmd
+}
+
+// NewGroup creates a Group dispatching to commands.
+func NewGroup(commands map[string]CmdFn) *Group {
+	return &Group{Cmd: New(commands, nil, nil)}
+}
+
+// Run looks up args[0] in the group's commands and calls it with the
+// remaining arguments. It implements CmdFn, so a Group can be registered
+// directly as a command, e.g. parent.Commands["user"] = group.Run.
+func (g *Group) Run(args []string) (string, error) {
+	if len(args) == 0 {
+		return g.helpList(), nil
+	}
+	name, rest := args[0], args[1:]
+	fn, ok := g.Commands[name]
+	if !ok {
+		return fmt.Sprintf("unrecognized command: %s\n", name), nil
+	}
+	return fn(rest)
+}
+
+// help is the "help" built-in that New registers for every Cmd unless the
+// caller has already provided their own "help" command. With no arguments
+// it lists the available commands; given a command name it prints that
+// command's description, or its subcommands if it was registered with a
+// Command whose Group is set.
+func (c *Cmd) help(args []string) (string, error) {
+	if len(args) == 0 {
+		return c.helpList(), nil
+	}
+	return c.helpCommand(args[0])
+}
+
+// helpList renders a one-line-per-command summary of c.Commands, using
+// the Short description recorded by AddCommand when available.
+func (c *Cmd) helpList() string {
+	names := make([]string, 0, len(c.Commands))
+	for name := range c.Commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		short := ""
+		if doc := c.cmdDocs[name]; doc != nil {
+			short = doc.Short
+		}
+		if short == "" {
+			fmt.Fprintf(&b, "%s\n", name)
+		} else {
+			fmt.Fprintf(&b, "%-12s %s\n", name, short)
+		}
+	}
+	return b.String()
+}
+
+// helpCommand renders the description for a single command name.
+func (c *Cmd) helpCommand(name string) (string, error) {
+	if _, ok := c.Commands[name]; !ok {
+		return fmt.Sprintf("no such command: %s\n", name), nil
+	}
+
+	doc := c.cmdDocs[name]
+	if doc != nil && doc.Group != nil {
+		return fmt.Sprintf("%s subcommands:\n%s", name, doc.Group.helpList()), nil
+	}
+	if doc == nil || (doc.Short == "" && doc.Long == "") {
+		return fmt.Sprintf("%s\n", name), nil
+	}
+
+	text := doc.Long
+	if text == "" {
+		text = doc.Short
+	}
+	return fmt.Sprintf("%s: %s\n", name, text), nil
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHelpList(t *testing.T) {
+	out := &bytes.Buffer{}
+	c := New(nil, nil, out)
+	c.AddCommand(Command{
+		Name:  "greet",
+		Short: "say hello",
+		Run:   func(args []string) (string, error) { return "hi\n", nil },
+	})
+
+	if err := c.one("help"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := out.String(), "greet        say hello\nhelp\n"; got != want {
+		t.Fatalf("bad help listing: got %q, want %q", got, want)
+	}
+}
+
+func TestHelpCommand(t *testing.T) {
+	out := &bytes.Buffer{}
+	c := New(nil, nil, out)
+	c.AddCommand(Command{
+		Name:  "greet",
+		Short: "say hello",
+		Long:  "greet prints a greeting",
+		Run:   func(args []string) (string, error) { return "hi\n", nil },
+	})
+
+	if err := c.one("help greet"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := out.String(), "greet: greet prints a greeting\n"; got != want {
+		t.Fatalf("bad help output: got %q, want %q", got, want)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	out := &bytes.Buffer{}
+	users := NewGroup(map[string]CmdFn{
+		"add": func(args []string) (string, error) {
+			return "added " + args[0] + "\n", nil
+		},
+	})
+	c := New(map[string]CmdFn{"user": users.Run}, nil, out)
+
+	if err := c.one("user add alice"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := out.String(), "added alice\n"; got != want {
+		t.Fatalf("bad output: got %q, want %q", got, want)
+	}
+}
+
+func TestGroupHelp(t *testing.T) {
+	out := &bytes.Buffer{}
+	users := NewGroup(nil)
+	users.AddCommand(Command{
+		Name:  "add",
+		Short: "add a user",
+		Run: func(args []string) (string, error) {
+			return "added " + args[0] + "\n", nil
+		},
+	})
+
+	c := New(nil, nil, out)
+	c.AddCommand(Command{
+		Name:  "user",
+		Short: "manage users",
+		Run:   users.Run,
+		Group: users,
+	})
+
+	if err := c.one("help user"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := out.String(), "user subcommands:\n"+users.helpList(); got != want {
+		t.Fatalf("bad help output: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+)
+
+// LoopContext is like Loop but gives commands registered in
+// ContextCommands a context derived from ctx, and traps Ctrl-C (SIGINT)
+// so that a long running command can be interrupted without terminating
+// the loop.
+//
+// On each iteration, pressing Ctrl-C or the command's context exceeding
+// CommandTimeout cancels the context passed to the running command. The
+// loop then waits for the command to return, prints an interrupt notice,
+// and goes back to the prompt. After a timeout the notice is printed only
+// if the command returns an error wrapping context.DeadlineExceeded.
+//
+// Canceling ctx itself stops the loop, returning ctx's error. ctx is not
+// watched while the loop waits for input: a cancellation at that point
+// takes effect once the next line has been read, and that line is still
+// run, with a canceled context.
+func (c *Cmd) LoopContext(ctx context.Context) error {
+	rd := bufio.NewReader(c.In)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+
+	for {
+		if _, err := c.Out.Write([]byte(c.Prompt)); err != nil {
+			return err
+		}
+		line, err := rd.ReadBytes('\n')
+		if err != nil {
+			return err
+		}
+
+		if err := c.runWithInterrupt(ctx, sigCh, string(line)); err != nil {
+			return err
+		}
+	}
+}
+
+// runWithInterrupt runs one command. If sigCh fires first, it cancels the
+// command's context, waits for the command to return and prints an
+// interrupt notice. If ctx is canceled first, it does the same without the
+// notice and returns ctx's error. A command error wrapping
+// context.DeadlineExceeded is replaced by the interrupt notice.
+func (c *Cmd) runWithInterrupt(ctx context.Context, sigCh <-chan os.Signal, line string) error {
+	cmdCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.oneContext(cmdCtx, line)
+	}()
+
+	select {
+	case err := <-done:
+		if errors.Is(err, context.DeadlineExceeded) {
+			_, err := c.Out.Write([]byte("\ninterrupted\n"))
+			return err
+		}
+		return err
+	case <-sigCh:
+		cancel()
+		<-done
+		_, err := c.Out.Write([]byte("\ninterrupted\n"))
+		return err
+	case <-ctx.Done():
+		cancel()
+		<-done
+		return ctx.Err()
+	}
+}
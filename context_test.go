@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestOneContextCommand(t *testing.T) {
+	out := &bytes.Buffer{}
+	c := New(nil, nil, out)
+	c.ContextCommands = map[string]CmdFnContext{
+		"ctxecho": func(ctx context.Context, args []string) (string, error) {
+			return fmt.Sprintf("ctxecho %v\n", args), nil
+		},
+	}
+
+	if err := c.one("ctxecho a b"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := out.String(), "ctxecho [a b]\n"; got != want {
+		t.Fatalf("bad output: got %q, want %q", got, want)
+	}
+}
+
+func TestLoopContextTimeout(t *testing.T) {
+	in := bytes.NewBufferString("slow\n")
+	out := &bytes.Buffer{}
+	c := New(nil, in, out)
+	c.CommandTimeout = 10 * time.Millisecond
+	c.ContextCommands = map[string]CmdFnContext{
+		"slow": func(ctx context.Context, args []string) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}
+
+	// A command timing out must print an interrupt notice and return to
+	// the prompt rather than terminating the loop - only running out of
+	// input should end it.
+	err := c.LoopContext(context.Background())
+	if err != io.EOF {
+		t.Fatalf("expected io.EOF once input is exhausted, got %v", err)
+	}
+	if got, want := out.String(), c.Prompt+"\ninterrupted\n"+c.Prompt; got != want {
+		t.Fatalf("bad output: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,34 @@
+// This is an example of exposing cmd over the Redis RESP protocol.
+// Start the example and then connect with redis-cli -p 6379 to see it in action.
+package main
+
+import (
+	"fmt"
+	"github.com/kisielk/cmd"
+	"log"
+	"net"
+	"strings"
+)
+
+func hello(args []string) (string, error) {
+	if len(args) == 0 {
+		return "What's your name?", nil
+	}
+	return fmt.Sprintf("Hello, %s", strings.Join(args, " ")), nil
+}
+
+func main() {
+	ln, err := net.Listen("tcp", ":6379")
+	if err != nil {
+		log.Fatal("could not open port:", err)
+	}
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Println("couldn't accept connection:", err)
+			continue
+		}
+		c := cmd.New(map[string]cmd.CmdFn{"hello": hello}, conn, conn)
+		go c.RESPLoop()
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/kisielk/cmd"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -17,5 +18,15 @@ func hello(args []string) (string, error) {
 
 func main() {
 	c := cmd.New(map[string]cmd.CmdFn{"hello": hello}, os.Stdin, os.Stdout)
-	c.Loop()
+
+	if dir, err := os.UserCacheDir(); err == nil {
+		c.HistoryFile = filepath.Join(dir, "cmd-simple-example-history")
+		c.LoadHistory()
+		defer c.SaveHistory()
+	}
+
+	// LoopReadline provides line editing and history when stdin is a
+	// terminal, and falls back to Loop when it isn't (e.g. when piping a
+	// script in).
+	c.LoopReadline()
 }
@@ -1,10 +1,10 @@
-// This is a simple example of using cmd.go over a tcp socket.
+// This is a simple example of using cmd over a tcp socket.
 // Start the example and then telnet to the host on port 6000 to see it in action.
 package main
 
 import (
 	"fmt"
-	"github.com/kisielk/cmd.go"
+	"github.com/kisielk/cmd"
 	"log"
 	"net"
 	"strings"
@@ -17,6 +17,16 @@ func hello(args []string) (string, error) {
 	return fmt.Sprintf("Hello, %s\n", strings.Join(args, " ")), nil
 }
 
+// authorize requires a session user for every command except "login",
+// which is how a connection acquires one in the first place.
+func authorize(user, line string) bool {
+	if user != "" {
+		return true
+	}
+	fields := strings.Fields(line)
+	return len(fields) > 0 && fields[0] == "login"
+}
+
 func main() {
 	ln, err := net.Listen("tcp", ":6000")
 	if err != nil {
@@ -29,6 +39,18 @@ func main() {
 			continue
 		}
 		c := cmd.New(map[string]cmd.CmdFn{"hello": hello}, conn, conn)
+		c.AddCommand(cmd.Command{
+			Name:  "login",
+			Short: "authenticate as a user",
+			Run: func(args []string) (string, error) {
+				if len(args) == 0 {
+					return "usage: login <user>\n", nil
+				}
+				c.Session["user"] = args[0]
+				return fmt.Sprintf("logged in as %s\n", args[0]), nil
+			},
+		})
+		c.Use(cmd.AuthMiddleware(c, authorize))
 		go c.Loop()
 	}
 }
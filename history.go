@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// LoadHistory reads the lines previously saved by SaveHistory from
+// HistoryFile into the history used by LoopReadline's up/down arrow
+// recall and Ctrl-R search, and sets LastLine to the most recently
+// loaded entry so that EmptyLine's repeat-last-command behaviour also
+// survives a restart. It is a no-op if HistoryFile is empty, and returns
+// nil without error if the file doesn't exist yet.
+func (c *Cmd) LoadHistory() error {
+	if c.HistoryFile == "" {
+		return nil
+	}
+
+	f, err := os.Open(c.HistoryFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	c.history = nil
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		c.addHistory(scanner.Text())
+	}
+	if n := len(c.history); n > 0 {
+		c.LastLine = c.history[n-1]
+	}
+	return scanner.Err()
+}
+
+// SaveHistory writes the current history, one line per entry, to
+// HistoryFile. It is a no-op if HistoryFile is empty.
+func (c *Cmd) SaveHistory() error {
+	if c.HistoryFile == "" {
+		return nil
+	}
+
+	f, err := os.Create(c.HistoryFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, line := range c.history {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
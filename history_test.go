@@ -0,0 +1,74 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kisielk/cmd"
+)
+
+func TestHistoryLoadSaveRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "history")
+	dst := filepath.Join(dir, "history.out")
+
+	// A consecutive duplicate ("two" twice) should be collapsed on load.
+	if err := os.WriteFile(src, []byte("one\ntwo\ntwo\nthree\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := cmd.New(nil, nil, nil)
+	c.HistoryFile = src
+	if err := c.LoadHistory(); err != nil {
+		t.Fatalf("LoadHistory: %s", err)
+	}
+
+	c.HistoryFile = dst
+	if err := c.SaveHistory(); err != nil {
+		t.Fatalf("SaveHistory: %s", err)
+	}
+
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "one\ntwo\nthree\n"; string(got) != want {
+		t.Fatalf("bad saved history: got %q, want %q", got, want)
+	}
+}
+
+func TestLoadHistorySetsLastLine(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "history")
+	if err := os.WriteFile(src, []byte("one\ntwo\nthree\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := cmd.New(nil, nil, nil)
+	c.HistoryFile = src
+	if err := c.LoadHistory(); err != nil {
+		t.Fatalf("LoadHistory: %s", err)
+	}
+	if c.LastLine != "three" {
+		t.Fatalf("LastLine = %q, want %q", c.LastLine, "three")
+	}
+}
+
+func TestLoadHistoryMissingFile(t *testing.T) {
+	c := cmd.New(nil, nil, nil)
+	c.HistoryFile = filepath.Join(t.TempDir(), "does-not-exist")
+	if err := c.LoadHistory(); err != nil {
+		t.Fatalf("LoadHistory on missing file: %s", err)
+	}
+}
+
+func TestHistoryFileEmptyIsNoop(t *testing.T) {
+	c := cmd.New(nil, nil, nil)
+	if err := c.LoadHistory(); err != nil {
+		t.Fatalf("LoadHistory: %s", err)
+	}
+	if err := c.SaveHistory(); err != nil {
+		t.Fatalf("SaveHistory: %s", err)
+	}
+}
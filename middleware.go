@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Middleware wraps a CmdFn with cross-cutting behaviour such as logging,
+// authentication or rate limiting. See Use, LoggingMiddleware,
+// AuthMiddleware and RateLimit.
+type Middleware func(next CmdFn) CmdFn
+
+// Use appends mw to the middleware chain applied to commands looked up in
+// Commands. Middleware registered first runs outermost, so
+//
+//	c.Use(LoggingMiddleware(logger), AuthMiddleware(c, authorize))
+//
+// logs every attempt, including ones AuthMiddleware goes on to reject.
+func (c *Cmd) Use(mw ...Middleware) {
+	c.middleware = append(c.middleware, mw...)
+}
+
+// wrap composes fn with c.middleware, with the first registered
+// middleware outermost.
+func (c *Cmd) wrap(fn CmdFn) CmdFn {
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		fn = c.middleware[i](fn)
+	}
+	return fn
+}
+
+// LoggingMiddleware returns a Middleware that logs every command
+// invocation to logger, including its arguments and, if it returned one,
+// its error.
+func LoggingMiddleware(logger *log.Logger) Middleware {
+	return func(next CmdFn) CmdFn {
+		return func(args []string) (string, error) {
+			out, err := next(args)
+			if err != nil {
+				logger.Printf("command %v: %s", args, err)
+			} else {
+				logger.Printf("command %v", args)
+			}
+			return out, err
+		}
+	}
+}
+
+// AuthMiddleware returns a Middleware that calls authorize with the
+// "user" key from c.Session (empty if unset) and the full input line
+// before running the wrapped command, rejecting the command if authorize
+// returns false. A login command can populate c.Session["user"] once
+// authorize allows an unauthenticated "login" line through.
+//
+// Rejection is reported as regular command output, not an error, so that
+// a rejected command doesn't terminate the Loop/LoopReadline session -
+// the whole point of gating commands on login is that the user gets to
+// try again.
+func AuthMiddleware(c *Cmd, authorize func(user, line string) bool) Middleware {
+	return func(next CmdFn) CmdFn {
+		return func(args []string) (string, error) {
+			user, _ := c.Session["user"].(string)
+			if !authorize(user, c.LastLine) {
+				return "not authorized\n", nil
+			}
+			return next(args)
+		}
+	}
+}
+
+// RateLimit returns a Middleware that allows at most n command
+// invocations per duration per, rejecting commands once the limit is
+// reached until the next window starts. The limit is shared by every Cmd
+// the returned Middleware is installed on.
+//
+// Like AuthMiddleware, a rejected command reports "rate limit exceeded"
+// as output rather than an error, so the loop keeps running.
+func RateLimit(n int, per time.Duration) Middleware {
+	rl := &rateLimiter{max: n, interval: per}
+	return func(next CmdFn) CmdFn {
+		return func(args []string) (string, error) {
+			if !rl.allow() {
+				return "rate limit exceeded\n", nil
+			}
+			return next(args)
+		}
+	}
+}
+
+// rateLimiter is a simple fixed-window rate limiter.
+type rateLimiter struct {
+	mu       sync.Mutex
+	max      int
+	interval time.Duration
+	tokens   int
+	windowAt time.Time
+}
+
+func (r *rateLimiter) allow() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if r.windowAt.IsZero() || now.Sub(r.windowAt) >= r.interval {
+		r.windowAt = now
+		r.tokens = r.max
+	}
+	if r.tokens <= 0 {
+		return false
+	}
+	r.tokens--
+	return true
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	out := &bytes.Buffer{}
+	var order []string
+
+	mw := func(name string) Middleware {
+		return func(next CmdFn) CmdFn {
+			return func(args []string) (string, error) {
+				order = append(order, name)
+				return next(args)
+			}
+		}
+	}
+
+	c := New(map[string]CmdFn{
+		"ping": func(args []string) (string, error) { return "pong\n", nil },
+	}, nil, out)
+	c.Use(mw("first"), mw("second"))
+
+	if err := c.one("ping"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := out.String(), "pong\n"; got != want {
+		t.Fatalf("bad output: got %q, want %q", got, want)
+	}
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Fatalf("bad middleware order: %v", order)
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	out := &bytes.Buffer{}
+	c := New(map[string]CmdFn{
+		"secret": func(args []string) (string, error) { return "top secret\n", nil },
+	}, nil, out)
+	c.Use(AuthMiddleware(c, func(user, line string) bool {
+		return user == "alice"
+	}))
+
+	// A rejected command must not terminate the loop - the whole point is
+	// that the user gets to try again, e.g. after logging in.
+	if err := c.one("secret"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := out.String(), "not authorized\n"; got != want {
+		t.Fatalf("bad output: got %q, want %q", got, want)
+	}
+	out.Reset()
+
+	c.Session["user"] = "alice"
+	if err := c.one("secret"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := out.String(), "top secret\n"; got != want {
+		t.Fatalf("bad output: got %q, want %q", got, want)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	out := &bytes.Buffer{}
+	c := New(map[string]CmdFn{
+		"ping": func(args []string) (string, error) { return "pong\n", nil },
+	}, nil, out)
+	c.Use(RateLimit(1, time.Minute))
+
+	if err := c.one("ping"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := out.String(), "pong\n"; got != want {
+		t.Fatalf("bad output: got %q, want %q", got, want)
+	}
+	out.Reset()
+
+	// The second call is rate limited but must not terminate the loop.
+	if err := c.one("ping"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := out.String(), "rate limit exceeded\n"; got != want {
+		t.Fatalf("bad output: got %q, want %q", got, want)
+	}
+}
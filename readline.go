@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// LineReader reads a single line of input, typically presenting a prompt
+// and supporting interactive editing. It is used internally by
+// Cmd.LoopReadline.
+type LineReader interface {
+	// ReadLine writes prompt and returns the next line of input, without
+	// a trailing newline. io.EOF is returned when there is no more input.
+	ReadLine(prompt string) (line string, err error)
+
+	// Close restores any state LineReader changed (such as terminal mode)
+	// and releases its resources.
+	Close() error
+}
+
+// LoopReadline is like Loop but, when In is a terminal, it provides
+// line editing, up/down history recall, a Ctrl-R reverse history search,
+// and TAB completion of command names from Commands (and of arguments via
+// Completer).
+//
+// When In is not a terminal - for example a pipe, or the connection used
+// by the TCP example - LoopReadline transparently falls back to the same
+// line-reading behaviour as Loop.
+func (c *Cmd) LoopReadline() error {
+	f, ok := c.In.(*os.File)
+	if !ok || !isTerminal(f) {
+		return c.Loop()
+	}
+
+	rl, err := newTermLineReader(f, c.Out, c)
+	if err != nil {
+		return c.Loop()
+	}
+	defer rl.Close()
+
+	for {
+		line, err := rl.ReadLine(c.Prompt)
+		if err != nil {
+			return err
+		}
+		if err := c.one(line + "\n"); err != nil {
+			return err
+		}
+	}
+}
+
+// commandNames returns the sorted names of c.Commands that start with
+// prefix, used for TAB completion of the first word of a line.
+func (c *Cmd) commandNames(prefix string) []string {
+	var matches []string
+	for name := range c.Commands {
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// wordBoundaries returns the start and end offsets of the whitespace
+// delimited word that contains, or immediately precedes, pos in line.
+func wordBoundaries(line string, pos int) (start, end int) {
+	if pos > len(line) {
+		pos = len(line)
+	}
+	start = pos
+	for start > 0 && line[start-1] != ' ' {
+		start--
+	}
+	end = pos
+	for end < len(line) && line[end] != ' ' {
+		end++
+	}
+	return start, end
+}
+
+// writeString is a small convenience wrapper used by the terminal line
+// reader to ignore the byte count returned by io.Writer.
+func writeString(w io.Writer, s string) {
+	io.WriteString(w, s)
+}
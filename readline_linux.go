@@ -0,0 +1,56 @@
+//go:build linux
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	ioctlGetTermios = 0x5401 // TCGETS
+	ioctlSetTermios = 0x5402 // TCSETS
+)
+
+// termiosState holds the terminal attributes to restore on Close.
+type termiosState struct {
+	termios syscall.Termios
+}
+
+func isTerminal(f *os.File) bool {
+	var t syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), ioctlGetTermios, uintptr(unsafe.Pointer(&t)))
+	return errno == 0
+}
+
+// makeRaw puts f into raw mode, disabling echo and line buffering so input
+// can be read and interpreted a byte at a time, and returns the previous
+// state so it can be restored.
+func makeRaw(f *os.File) (*termiosState, error) {
+	var oldState syscall.Termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), ioctlGetTermios, uintptr(unsafe.Pointer(&oldState))); errno != 0 {
+		return nil, errno
+	}
+
+	newState := oldState
+	newState.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
+	newState.Oflag &^= syscall.OPOST
+	newState.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN | syscall.ISIG
+	newState.Cflag &^= syscall.CSIZE | syscall.PARENB
+	newState.Cflag |= syscall.CS8
+	newState.Cc[syscall.VMIN] = 1
+	newState.Cc[syscall.VTIME] = 0
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), ioctlSetTermios, uintptr(unsafe.Pointer(&newState))); errno != 0 {
+		return nil, errno
+	}
+	return &termiosState{termios: oldState}, nil
+}
+
+func restoreTermios(f *os.File, state *termiosState) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), ioctlSetTermios, uintptr(unsafe.Pointer(&state.termios))); errno != 0 {
+		return errno
+	}
+	return nil
+}
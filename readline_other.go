@@ -0,0 +1,20 @@
+//go:build !linux
+
+package cmd
+
+import (
+	"errors"
+	"os"
+)
+
+var errUnsupportedPlatform = errors.New("cmd: raw terminal mode is not supported on this platform")
+
+// isTerminal always reports false on platforms without a raw-mode
+// implementation, so LoopReadline falls back to the plain Loop behaviour.
+func isTerminal(f *os.File) bool { return false }
+
+func makeRaw(f *os.File) (*termiosState, error) { return nil, errUnsupportedPlatform }
+
+func restoreTermios(f *os.File, state *termiosState) error { return nil }
+
+type termiosState struct{}
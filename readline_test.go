@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWordBoundaries(t *testing.T) {
+	tests := []struct {
+		Line       string
+		Pos        int
+		Start, End int
+	}{
+		{"", 0, 0, 0},
+		{"hello", 5, 0, 5},
+		{"hello world", 5, 0, 5},
+		{"hello world", 6, 6, 11},
+		{"hello world", 8, 6, 11},
+	}
+	for i, test := range tests {
+		start, end := wordBoundaries(test.Line, test.Pos)
+		if start != test.Start || end != test.End {
+			t.Fatalf("%d: wordBoundaries(%q, %d) = %d, %d want %d, %d", i, test.Line, test.Pos, start, end, test.Start, test.End)
+		}
+	}
+}
+
+func TestCommandNames(t *testing.T) {
+	c := New(map[string]CmdFn{
+		"help":  nil,
+		"hello": nil,
+		"quit":  nil,
+	}, nil, nil)
+
+	got := c.commandNames("he")
+	want := []string{"hello", "help"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("commandNames(\"he\") = %v, want %v", got, want)
+	}
+}
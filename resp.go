@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// RESPSimpleString marks a string that should be encoded as a RESP simple
+// string (e.g. "+OK\r\n") rather than the default bulk string encoding.
+type RESPSimpleString string
+
+// RESPOK is the conventional "+OK\r\n" reply used by commands that have
+// no other result to report.
+const RESPOK = RESPSimpleString("OK")
+
+// RESPLoop speaks the Redis RESP protocol on c.In/c.Out, exposing
+// RESPCommands - and, as a fallback, the plain string Commands - as a
+// Redis-compatible TCP service consumable by redis-cli and Redis client
+// libraries.
+//
+// Both inline commands and RESP arrays of bulk strings are accepted as
+// input. RESPLoop keeps reading and dispatching commands from c.In
+// without waiting for a reply to be consumed, so pipelined requests are
+// supported.
+//
+// A CmdFnRESP's reply is encoded according to its type:
+//
+//	nil             RESP null bulk string
+//	string, []byte  bulk string
+//	RESPSimpleString simple string, e.g. RESPOK
+//	int, int64      integer
+//	error           RESP error (from CmdFnRESP's err return, or a command's CmdFn error)
+//	[]string        array of bulk strings
+//	[]interface{}   array, encoded recursively
+//
+// A plain Commands entry is run with its string output encoded as a bulk
+// string, so existing handlers can be exposed over RESP unchanged.
+//
+// A reply of any other type can't be encoded; RESPLoop reports that to
+// the client as a RESP error for that command and continues serving the
+// connection rather than terminating it.
+func (c *Cmd) RESPLoop() error {
+	r := bufio.NewReader(c.In)
+	for {
+		args, err := readRESPCommand(r)
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		name := strings.ToLower(string(args[0]))
+		c.LastLine = string(bytes.Join(args, []byte(" ")))
+		reply, cmderr := c.dispatchRESP(name, args[1:])
+		if err := writeRESPReply(c.Out, reply, cmderr); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Cmd) dispatchRESP(name string, args [][]byte) (interface{}, error) {
+	if fn, ok := c.RESPCommands[name]; ok {
+		return fn(args)
+	}
+	if fn, ok := c.Commands[name]; ok {
+		strArgs := make([]string, len(args))
+		for i, a := range args {
+			strArgs[i] = string(a)
+		}
+		return c.wrap(fn)(strArgs)
+	}
+	return nil, fmt.Errorf("unknown command '%s'", name)
+}
+
+// readRESPCommand reads one command from r, either a RESP array of bulk
+// strings (the format used by Redis clients) or an inline,
+// whitespace-separated command.
+func readRESPCommand(r *bufio.Reader) ([][]byte, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if len(line) == 0 {
+		return nil, nil
+	}
+
+	if line[0] != '*' {
+		fields := strings.Fields(line)
+		args := make([][]byte, len(fields))
+		for i, f := range fields {
+			args[i] = []byte(f)
+		}
+		return args, nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("resp: invalid array header %q", line)
+	}
+
+	args := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		header = strings.TrimRight(header, "\r\n")
+		if len(header) == 0 || header[0] != '$' {
+			return nil, fmt.Errorf("resp: expected bulk string header, got %q", header)
+		}
+		size, err := strconv.Atoi(header[1:])
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("resp: invalid bulk string length %q", header)
+		}
+
+		buf := make([]byte, size+2) // payload plus trailing CRLF
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, buf[:size])
+	}
+	return args, nil
+}
+
+// writeRESPReply encodes reply and err as a single RESP reply and writes
+// it to w. If reply is of a type encodeRESPValue doesn't know how to
+// encode, that is reported to the client as a RESP error instead of
+// being treated as an I/O failure - only a failed write to w is returned
+// as an error, so one command with a malformed reply doesn't take down
+// the rest of the RESPLoop session.
+func writeRESPReply(w io.Writer, reply interface{}, err error) error {
+	if err != nil {
+		_, werr := fmt.Fprintf(w, "-ERR %s\r\n", err)
+		return werr
+	}
+	buf, encErr := encodeRESPValue(reply)
+	if encErr != nil {
+		_, werr := fmt.Fprintf(w, "-ERR %s\r\n", encErr)
+		return werr
+	}
+	_, werr := w.Write(buf)
+	return werr
+}
+
+// encodeRESPValue renders v as a RESP value. It does no I/O itself, so a
+// later element of an array failing to encode can't leave a partial,
+// malformed reply already written to the client.
+func encodeRESPValue(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	switch val := v.(type) {
+	case nil:
+		b.WriteString("$-1\r\n")
+	case RESPSimpleString:
+		fmt.Fprintf(&b, "+%s\r\n", val)
+	case string:
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(val), val)
+	case []byte:
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(val), val)
+	case int:
+		fmt.Fprintf(&b, ":%d\r\n", val)
+	case int64:
+		fmt.Fprintf(&b, ":%d\r\n", val)
+	case []string:
+		fmt.Fprintf(&b, "*%d\r\n", len(val))
+		for _, s := range val {
+			enc, err := encodeRESPValue(s)
+			if err != nil {
+				return nil, err
+			}
+			b.Write(enc)
+		}
+	case []interface{}:
+		fmt.Fprintf(&b, "*%d\r\n", len(val))
+		for _, item := range val {
+			enc, err := encodeRESPValue(item)
+			if err != nil {
+				return nil, err
+			}
+			b.Write(enc)
+		}
+	default:
+		return nil, fmt.Errorf("resp: unsupported reply type %T", v)
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,100 @@
+package cmd_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/kisielk/cmd"
+)
+
+func TestRESPLoopInline(t *testing.T) {
+	in := bytes.NewBufferString("hello Alice\r\n")
+	out := &bytes.Buffer{}
+	c := cmd.New(map[string]cmd.CmdFn{
+		"hello": func(args []string) (string, error) {
+			return fmt.Sprintf("Hello, %s", args[0]), nil
+		},
+	}, in, out)
+
+	if err := c.RESPLoop(); err != io.EOF {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := out.String(), "$12\r\nHello, Alice\r\n"; got != want {
+		t.Fatalf("bad reply: got %q, want %q", got, want)
+	}
+}
+
+func TestRESPLoopArray(t *testing.T) {
+	in := bytes.NewBufferString("*2\r\n$5\r\nhello\r\n$3\r\nBob\r\n")
+	out := &bytes.Buffer{}
+	c := cmd.New(nil, in, out)
+	c.RESPCommands = map[string]cmd.CmdFnRESP{
+		"hello": func(args [][]byte) (interface{}, error) {
+			return "Hello, " + string(args[0]), nil
+		},
+	}
+
+	if err := c.RESPLoop(); err != io.EOF {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := out.String(), "$10\r\nHello, Bob\r\n"; got != want {
+		t.Fatalf("bad reply: got %q, want %q", got, want)
+	}
+}
+
+func TestRESPLoopUnknownCommand(t *testing.T) {
+	in := bytes.NewBufferString("nope\r\n")
+	out := &bytes.Buffer{}
+	c := cmd.New(nil, in, out)
+
+	if err := c.RESPLoop(); err != io.EOF {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := out.String(), "-ERR unknown command 'nope'\r\n"; got != want {
+		t.Fatalf("bad reply: got %q, want %q", got, want)
+	}
+}
+
+func TestRESPLoopUnsupportedReplyType(t *testing.T) {
+	in := bytes.NewBufferString("weird\r\nping\r\n")
+	out := &bytes.Buffer{}
+	c := cmd.New(nil, in, out)
+	c.RESPCommands = map[string]cmd.CmdFnRESP{
+		"weird": func(args [][]byte) (interface{}, error) {
+			return true, nil
+		},
+		"ping": func(args [][]byte) (interface{}, error) {
+			return cmd.RESPOK, nil
+		},
+	}
+
+	// An unencodable reply must be reported to the client as a RESP
+	// error for that command, not drop the connection before the
+	// pipelined "ping" that follows it runs.
+	if err := c.RESPLoop(); err != io.EOF {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := out.String(), "-ERR resp: unsupported reply type bool\r\n+OK\r\n"; got != want {
+		t.Fatalf("bad reply: got %q, want %q", got, want)
+	}
+}
+
+func TestRESPLoopPipelining(t *testing.T) {
+	in := bytes.NewBufferString("ping\r\nping\r\n")
+	out := &bytes.Buffer{}
+	c := cmd.New(nil, in, out)
+	c.RESPCommands = map[string]cmd.CmdFnRESP{
+		"ping": func(args [][]byte) (interface{}, error) {
+			return cmd.RESPOK, nil
+		},
+	}
+
+	if err := c.RESPLoop(); err != io.EOF {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := out.String(), "+OK\r\n+OK\r\n"; got != want {
+		t.Fatalf("bad reply: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RunScript executes the commands in r, one per line, for batch use such
+// as `myapp < script.txt`. Blank lines and lines whose first non-blank
+// character is '#' are skipped.
+//
+// RunScript stops and returns a command's error as soon as one occurs,
+// unless ContinueOnError is set, in which case the error is written to
+// Out and execution continues with the next line.
+func (c *Cmd) RunScript(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if err := c.one(line + "\n"); err != nil {
+			if !c.ContinueOnError {
+				return err
+			}
+			fmt.Fprintf(c.Out, "error: %s\n", err)
+		}
+	}
+	return scanner.Err()
+}
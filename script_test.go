@@ -0,0 +1,60 @@
+package cmd_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kisielk/cmd"
+)
+
+func TestRunScript(t *testing.T) {
+	out := &bytes.Buffer{}
+	c := cmd.New(map[string]cmd.CmdFn{
+		"echo": func(args []string) (string, error) {
+			return fmt.Sprintf("%v\n", args), nil
+		},
+	}, nil, out)
+
+	script := "# a comment\necho one\n\necho two\n"
+	if err := c.RunScript(strings.NewReader(script)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := out.String(), "[one]\n[two]\n"; got != want {
+		t.Fatalf("bad output: got %q, want %q", got, want)
+	}
+}
+
+func TestRunScriptStopsOnError(t *testing.T) {
+	out := &bytes.Buffer{}
+	c := cmd.New(map[string]cmd.CmdFn{
+		"fail": func(args []string) (string, error) { return "", fmt.Errorf("boom") },
+		"echo": func(args []string) (string, error) { return "echoed\n", nil },
+	}, nil, out)
+
+	script := "fail\necho nope\n"
+	if err := c.RunScript(strings.NewReader(script)); err == nil {
+		t.Fatalf("expected error")
+	}
+	if got := out.String(); got != "" {
+		t.Fatalf("expected no output, got %q", got)
+	}
+}
+
+func TestRunScriptContinueOnError(t *testing.T) {
+	out := &bytes.Buffer{}
+	c := cmd.New(map[string]cmd.CmdFn{
+		"fail": func(args []string) (string, error) { return "", fmt.Errorf("boom") },
+		"echo": func(args []string) (string, error) { return "echoed\n", nil },
+	}, nil, out)
+	c.ContinueOnError = true
+
+	script := "fail\necho yes\n"
+	if err := c.RunScript(strings.NewReader(script)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := out.String(), "error: boom\nechoed\n"; got != want {
+		t.Fatalf("bad output: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+)
+
+// ShellTokens splits line into tokens using POSIX shell-like quoting rules.
+// Single-quoted and double-quoted substrings are treated as a single token
+// with the quotes removed. Outside quotes a backslash escapes the character
+// that follows it; inside quotes it escapes only the quote character that
+// opened them and is otherwise kept as is. It can be assigned to Cmd.Tokens to allow arguments
+// containing spaces, e.g.:
+//
+//	c.Tokens = cmd.ShellTokens
+//
+// ShellTokens returns an error if line contains an unterminated quote.
+func ShellTokens(line string) (tokens []string, err error) {
+	var (
+		tok     []rune
+		inToken bool
+		quote   rune
+	)
+	runes := []rune(line)
+
+	flush := func() {
+		if inToken {
+			tokens = append(tokens, string(tok))
+			tok = tok[:0]
+			inToken = false
+		}
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if quote != 0 {
+			if r == '\\' && i+1 < len(runes) && runes[i+1] == quote {
+				tok = append(tok, quote)
+				i++
+				continue
+			}
+			if r == quote {
+				quote = 0
+				continue
+			}
+			tok = append(tok, r)
+			continue
+		}
+
+		switch r {
+		case '\'', '"':
+			quote = r
+			inToken = true
+		case '\\':
+			if i+1 < len(runes) {
+				tok = append(tok, runes[i+1])
+				i++
+				inToken = true
+			}
+		case ' ', '\t', '\n', '\r':
+			flush()
+		default:
+			tok = append(tok, r)
+			inToken = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("cmd: unterminated %c quote in %q", quote, line)
+	}
+
+	flush()
+	return tokens, nil
+}
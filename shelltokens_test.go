@@ -0,0 +1,38 @@
+package cmd_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kisielk/cmd"
+)
+
+var shellTokensTests = []struct {
+	In          string
+	Out         []string
+	ShouldError bool
+}{
+	{"", nil, false},
+	{"  ", nil, false},
+	{"greet", []string{"greet"}, false},
+	{"greet John Doe", []string{"greet", "John", "Doe"}, false},
+	{`greet "John Doe" 'it\'s me'`, []string{"greet", "John Doe", "it's me"}, false},
+	{`foo\ bar`, []string{"foo bar"}, false},
+	{`"unterminated`, nil, true},
+	{`'unterminated`, nil, true},
+}
+
+func TestShellTokens(t *testing.T) {
+	for i, test := range shellTokensTests {
+		tokens, err := cmd.ShellTokens(test.In)
+		if test.ShouldError && err == nil {
+			t.Fatalf("%d: expected error but got nil", i)
+		} else if !test.ShouldError && err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+
+		if !test.ShouldError && !reflect.DeepEqual(tokens, test.Out) {
+			t.Fatalf("%d: bad tokens: got %#v, want %#v", i, tokens, test.Out)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	keyCtrlA = 1
+	keyCtrlC = 3
+	keyCtrlD = 4
+	keyCtrlE = 5
+	keyCtrlR = 18
+	keyTab   = 9
+	keyBS    = 8
+	keyDel   = 127
+	keyEnter = '\r'
+	keyEsc   = 27
+)
+
+// termLineReader is the LineReader used by LoopReadline when In is a
+// terminal. It implements basic Emacs-style line editing, up/down history
+// recall, a Ctrl-R reverse history search, and TAB completion.
+type termLineReader struct {
+	f     *os.File
+	out   io.Writer
+	cmd   *Cmd
+	state *termiosState
+	in    *bufio.Reader
+
+	// historyPos indexes into cmd.history while navigating with the
+	// up/down arrow keys. The history entries themselves live on cmd so
+	// that SaveHistory can persist them across restarts.
+	historyPos int
+}
+
+func newTermLineReader(f *os.File, out io.Writer, cmd *Cmd) (*termLineReader, error) {
+	state, err := makeRaw(f)
+	if err != nil {
+		return nil, err
+	}
+	return &termLineReader{f: f, out: out, cmd: cmd, state: state, in: bufio.NewReader(f)}, nil
+}
+
+func (r *termLineReader) Close() error {
+	return restoreTermios(r.f, r.state)
+}
+
+// ReadLine implements LineReader.
+func (r *termLineReader) ReadLine(prompt string) (string, error) {
+	buf := []rune{}
+	pos := 0
+	r.historyPos = len(r.cmd.history)
+
+	redraw := func() {
+		writeString(r.out, "\r"+prompt+string(buf)+"\x1b[K")
+		if back := len(buf) - pos; back > 0 {
+			writeString(r.out, "\x1b["+strconv.Itoa(back)+"D")
+		}
+	}
+
+	writeString(r.out, prompt)
+	for {
+		b, err := r.in.ReadByte()
+		if err != nil {
+			return "", err
+		}
+
+		switch b {
+		case keyEnter, '\n':
+			writeString(r.out, "\r\n")
+			line := string(buf)
+			r.cmd.addHistory(line)
+			return line, nil
+
+		case keyCtrlD:
+			if len(buf) == 0 {
+				writeString(r.out, "\r\n")
+				return "", io.EOF
+			}
+
+		case keyCtrlC:
+			writeString(r.out, "^C\r\n")
+			buf = buf[:0]
+			pos = 0
+			writeString(r.out, prompt)
+
+		case keyBS, keyDel:
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+				redraw()
+			}
+
+		case keyCtrlA:
+			pos = 0
+			redraw()
+
+		case keyCtrlE:
+			pos = len(buf)
+			redraw()
+
+		case keyTab:
+			buf, pos = r.complete(buf, pos)
+			redraw()
+
+		case keyCtrlR:
+			line, err := r.reverseSearch(prompt)
+			if err != nil {
+				return "", err
+			}
+			buf = []rune(line)
+			pos = len(buf)
+			redraw()
+
+		case keyEsc:
+			seq1, err := r.in.ReadByte()
+			if err != nil {
+				return "", err
+			}
+			if seq1 != '[' {
+				continue
+			}
+			seq2, err := r.in.ReadByte()
+			if err != nil {
+				return "", err
+			}
+			switch seq2 {
+			case 'A': // up
+				buf, pos = r.historyPrev(buf)
+				redraw()
+			case 'B': // down
+				buf, pos = r.historyNext()
+				redraw()
+			case 'C': // right
+				if pos < len(buf) {
+					pos++
+					redraw()
+				}
+			case 'D': // left
+				if pos > 0 {
+					pos--
+					redraw()
+				}
+			}
+
+		default:
+			if b >= 32 {
+				buf = append(buf[:pos], append([]rune{rune(b)}, buf[pos:]...)...)
+				pos++
+				redraw()
+			}
+		}
+	}
+}
+
+func (r *termLineReader) historyPrev(cur []rune) ([]rune, int) {
+	if r.historyPos == 0 {
+		return cur, len(cur)
+	}
+	r.historyPos--
+	line := []rune(r.cmd.history[r.historyPos])
+	return line, len(line)
+}
+
+func (r *termLineReader) historyNext() ([]rune, int) {
+	if r.historyPos >= len(r.cmd.history) {
+		return []rune{}, 0
+	}
+	r.historyPos++
+	if r.historyPos == len(r.cmd.history) {
+		return []rune{}, 0
+	}
+	line := []rune(r.cmd.history[r.historyPos])
+	return line, len(line)
+}
+
+// reverseSearch implements a minimal Ctrl-R incremental history search: it
+// reads characters into a search term and reports the most recent matching
+// history entry, until Enter accepts the match or Ctrl-C cancels it.
+func (r *termLineReader) reverseSearch(prompt string) (string, error) {
+	term := []rune{}
+	match := ""
+	for {
+		writeString(r.out, "\r(reverse-i-search)`"+string(term)+"': "+match+"\x1b[K")
+
+		b, err := r.in.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		switch b {
+		case keyEnter, '\n':
+			writeString(r.out, "\r\n")
+			return match, nil
+		case keyCtrlC:
+			writeString(r.out, "\r\n")
+			return "", nil
+		case keyCtrlR:
+			match = r.searchHistory(string(term), match)
+		case keyBS, keyDel:
+			if len(term) > 0 {
+				term = term[:len(term)-1]
+				match = r.searchHistory(string(term), "")
+			}
+		default:
+			if b >= 32 {
+				term = append(term, rune(b))
+				match = r.searchHistory(string(term), "")
+			}
+		}
+	}
+}
+
+// searchHistory returns the most recent history entry containing term,
+// searching strictly before the current match when one is given so that
+// repeated Ctrl-R presses cycle to older matches.
+func (r *termLineReader) searchHistory(term, after string) string {
+	if term == "" {
+		return ""
+	}
+	found := false
+	for i := len(r.cmd.history) - 1; i >= 0; i-- {
+		entry := r.cmd.history[i]
+		if after != "" {
+			if entry == after {
+				found = true
+			}
+			if !found {
+				continue
+			}
+			if entry == after {
+				continue
+			}
+		}
+		if strings.Contains(entry, term) {
+			return entry
+		}
+	}
+	return ""
+}
+
+// complete expands the word under the cursor using command name completion
+// for the first word, or Cmd.Completer for subsequent words.
+func (r *termLineReader) complete(buf []rune, pos int) ([]rune, int) {
+	line := string(buf)
+	start, end := wordBoundaries(line, pos)
+	word := line[start:end]
+
+	var candidates []string
+	if start == 0 {
+		candidates = r.cmd.commandNames(word)
+	} else if r.cmd.Completer != nil {
+		candidates = r.cmd.Completer(line, pos)
+	}
+
+	switch len(candidates) {
+	case 0:
+		return buf, pos
+	case 1:
+		newLine := line[:start] + candidates[0] + " " + line[end:]
+		newPos := start + len(candidates[0]) + 1
+		return []rune(newLine), newPos
+	default:
+		writeString(r.out, "\r\n")
+		for _, c := range candidates {
+			writeString(r.out, c+"  ")
+		}
+		writeString(r.out, "\r\n")
+		return buf, pos
+	}
+}